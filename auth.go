@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+)
+
+type contextKey int
+
+const userContextKey contextKey = iota
+
+// Claims is the JWT payload issued by Authenticator and validated on every
+// protected request.
+type Claims struct {
+	jwt.Payload
+	User string `json:"user,omitempty"`
+}
+
+// Authenticator issues and verifies HMAC-SHA256 JWTs. It keeps one signing
+// key per KID so keys can be rotated without invalidating tokens signed
+// under the previous one.
+type Authenticator struct {
+	keys       map[string]*jwt.HMACSHA
+	currentKID string
+	audience   jwt.Audience
+	ttl        time.Duration
+	users      map[string]string
+}
+
+func NewAuthenticator(cfg *Config) *Authenticator {
+	a := &Authenticator{
+		keys:       map[string]*jwt.HMACSHA{cfg.JWTKID: jwt.NewHS256([]byte(cfg.JWTSecret))},
+		currentKID: cfg.JWTKID,
+		audience:   jwt.Audience{cfg.JWTAudience},
+		ttl:        cfg.JWTTTL,
+		users:      cfg.AuthUsers,
+	}
+
+	// During a rotation window, keep accepting tokens signed under the
+	// retiring key so they don't get invalidated before they expire. New
+	// tokens are always signed under cfg.JWTKID.
+	if cfg.JWTKIDOld != "" && cfg.JWTSecretOld != "" {
+		a.AddKey(cfg.JWTKIDOld, cfg.JWTSecretOld)
+	}
+
+	return a
+}
+
+// AddKey registers an additional verification key under kid, for rotating
+// the signing key without breaking tokens issued under the old one.
+func (a *Authenticator) AddKey(kid, secret string) {
+	a.keys[kid] = jwt.NewHS256([]byte(secret))
+}
+
+// CheckCredentials reports whether password matches the configured password
+// for user, in constant time so a failed lookup and a wrong password take
+// the same time to reject.
+func (a *Authenticator) CheckCredentials(user, password string) bool {
+	want, ok := a.users[user]
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(want), []byte(password)) == 1
+}
+
+// IssueToken signs a new token for user, expiring after the configured TTL.
+func (a *Authenticator) IssueToken(user string) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		Payload: jwt.Payload{
+			Audience:       a.audience,
+			IssuedAt:       jwt.NumericDate(now),
+			ExpirationTime: jwt.NumericDate(now.Add(a.ttl)),
+		},
+		User: user,
+	}
+
+	token, err := jwt.Sign(claims, a.keys[a.currentKID], jwt.KeyID(a.currentKID))
+	if err != nil {
+		return "", err
+	}
+	return string(token), nil
+}
+
+// Verify validates signature, expiry, and audience, returning the claims on
+// success.
+func (a *Authenticator) Verify(token string) (*Claims, error) {
+	kid, err := peekKID(token)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := a.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	var claims Claims
+	validatePayload := jwt.ValidatePayload(&claims.Payload,
+		jwt.ExpirationTimeValidator(time.Now()),
+		jwt.AudienceValidator(a.audience),
+	)
+
+	if _, err := jwt.Verify([]byte(token), key, &claims, validatePayload); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+// peekKID reads the `kid` header field without verifying the signature, so
+// Verify knows which key to check against.
+func peekKID(token string) (string, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return "", errors.New("malformed token")
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", err
+	}
+
+	var header struct {
+		KID string `json:"kid"`
+	}
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return "", err
+	}
+	return header.KID, nil
+}
+
+// requireAuth rejects requests that don't carry a valid bearer token and
+// injects the authenticated user into the request context otherwise.
+func (s *Server) requireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		tokenStr, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || tokenStr == "" {
+			http.Error(w, "Missing bearer token", http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := s.auth.Verify(tokenStr)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userContextKey, claims.User)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+type tokenRequest struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+type tokenResponse struct {
+	Token     string `json:"token"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+func (s *Server) handleIssueToken(w http.ResponseWriter, r *http.Request) {
+	var req tokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.User == "" || req.Password == "" {
+		http.Error(w, "Request must include a non-empty user and password", http.StatusBadRequest)
+		return
+	}
+
+	if !s.auth.CheckCredentials(req.User, req.Password) {
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	token, err := s.auth.IssueToken(req.User)
+	if err != nil {
+		http.Error(w, "Failed to issue token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	resp := tokenResponse{Token: token, ExpiresIn: int(s.auth.ttl.Seconds())}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode token response", http.StatusInternalServerError)
+	}
+}
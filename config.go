@@ -0,0 +1,42 @@
+package main
+
+import (
+	"time"
+
+	"github.com/caarlos0/env/v10"
+)
+
+// Config holds runtime configuration sourced from the environment.
+type Config struct {
+	Port        int    `env:"PORT" envDefault:"4000"`
+	StoreDriver string `env:"STORE_DRIVER" envDefault:"memory"`
+	BoltPath    string `env:"BOLT_PATH" envDefault:"data.db"`
+	DatabaseURL string `env:"DATABASE_URL"`
+
+	JWTSecret   string        `env:"JWT_SECRET"`
+	JWTKID      string        `env:"JWT_KID" envDefault:"v1"`
+	JWTTTL      time.Duration `env:"JWT_TTL" envDefault:"1h"`
+	JWTAudience string        `env:"JWT_AUDIENCE" envDefault:"assignment-2"`
+	// JWTSecretOld/JWTKIDOld register a second, retiring verification key so
+	// tokens issued before a rotation keep validating until they expire.
+	// New tokens are always signed under JWTKID/JWTSecret. Leave both unset
+	// outside of a rotation window.
+	JWTSecretOld string `env:"JWT_SECRET_OLD"`
+	JWTKIDOld    string `env:"JWT_KID_OLD"`
+	// AuthUsers maps usernames to their password, e.g. "alice:s3cret,bob:hunter2".
+	// POST /auth/token only issues a token when the submitted credentials
+	// match an entry here.
+	AuthUsers map[string]string `env:"AUTH_USERS" envKeyValSeparator:":"`
+
+	PostDataRateLimitRPS   float64 `env:"POST_DATA_RATE_LIMIT_RPS" envDefault:"100"`
+	PostDataRateLimitBurst int     `env:"POST_DATA_RATE_LIMIT_BURST" envDefault:"100"`
+}
+
+// LoadConfig parses Config from the environment.
+func LoadConfig() (*Config, error) {
+	cfg := &Config{}
+	if err := env.Parse(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
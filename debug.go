@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+type debugInfo struct {
+	UptimeSeconds   float64 `json:"uptime_seconds"`
+	Goroutines      int     `json:"goroutines"`
+	InFlight        int64   `json:"in_flight_requests"`
+	TotalRequests   int64   `json:"total_requests"`
+	AllocBytes      uint64  `json:"alloc_bytes"`
+	TotalAllocBytes uint64  `json:"total_alloc_bytes"`
+	NumGC           uint32  `json:"num_gc"`
+}
+
+func (s *Server) handleDebug(w http.ResponseWriter, r *http.Request) {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	info := debugInfo{
+		UptimeSeconds:   time.Since(s.startTime).Seconds(),
+		Goroutines:      runtime.NumGoroutine(),
+		InFlight:        s.metrics.InFlight(),
+		TotalRequests:   s.metrics.RequestCount(),
+		AllocBytes:      memStats.Alloc,
+		TotalAllocBytes: memStats.TotalAlloc,
+		NumGC:           memStats.NumGC,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(info); err != nil {
+		http.Error(w, "Failed to encode debug info", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-s.stopChan:
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
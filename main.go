@@ -10,83 +10,151 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
-	"sync"
+	"strings"
 	"syscall"
 	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
 )
 
 type Server struct {
-	data       map[string]string
-	mu         sync.Mutex
-	requests   int
-	stopChan   chan struct{}
-	httpServer *http.Server
+	store           Store
+	metrics         *Metrics
+	registry        *prometheus.Registry
+	auth            *Authenticator
+	postDataLimiter *rate.Limiter
+	middlewares     []func(http.Handler) http.Handler
+	startTime       time.Time
+	stopChan        chan struct{}
+	httpServer      *http.Server
 }
 
-func NewServer() *Server {
+func NewServer(store Store, cfg *Config) *Server {
+	registry := prometheus.NewRegistry()
 	return &Server{
-		data:     make(map[string]string),
-		stopChan: make(chan struct{}),
+		store:           store,
+		metrics:         NewMetrics(registry),
+		registry:        registry,
+		auth:            NewAuthenticator(cfg),
+		postDataLimiter: newRateLimiter(cfg.PostDataRateLimitRPS, cfg.PostDataRateLimitBurst),
+		startTime:       time.Now(),
+		stopChan:        make(chan struct{}),
 	}
 }
 
+// conflictError is the structured body returned when a batch write is
+// rejected because of a mode violation (duplicate key under insert, or
+// missing key under replace).
+type conflictError struct {
+	Error string   `json:"error"`
+	Keys  []string `json:"keys"`
+}
+
 func (s *Server) handlePostData(w http.ResponseWriter, r *http.Request) {
 	var input map[string]string
-	s.mu.Lock()
-	s.requests++
-	s.mu.Unlock()
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	mode := WriteMode(r.URL.Query().Get("mode"))
+	if mode == "" {
+		mode = ModeInsert
+	}
 
-	err := json.NewDecoder(r.Body).Decode(&input)
+	conflicts, err := s.store.PutBatch(r.Context(), input, mode)
 	if err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		http.Error(w, fmt.Sprintf("Invalid write: %v", err), http.StatusBadRequest)
 		return
 	}
 
-	s.mu.Lock()
-	for k, v := range input {
-		if _, exists := s.data[k]; exists {
-			errMsg := fmt.Sprintf("Duplicate entry for key: %s", k)
-			http.Error(w, errMsg, http.StatusBadRequest)
-		}
-		s.data[k] = v
+	if len(conflicts) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(conflictError{Error: "conflicting keys", Keys: conflicts})
+		return
 	}
-	s.mu.Unlock()
 
 	w.WriteHeader(http.StatusCreated)
 }
 
+// etagToVersion parses an If-Match header of the form `"<version>"` into the
+// version it names. A missing header means "key must not exist yet".
+func etagToVersion(etag string) (int, error) {
+	etag = strings.Trim(etag, `"`)
+	if etag == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(etag)
+}
+
+func versionToEtag(version int) string {
+	return fmt.Sprintf("%q", strconv.Itoa(version))
+}
+
+func (s *Server) handlePutData(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	var body struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	expectedVersion, err := etagToVersion(r.Header.Get("If-Match"))
+	if err != nil {
+		http.Error(w, "Invalid If-Match header", http.StatusBadRequest)
+		return
+	}
+
+	version, err := s.store.PutIfVersion(r.Context(), key, body.Value, expectedVersion)
+	if errors.Is(err, ErrVersionMismatch) {
+		http.Error(w, "Version mismatch", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to store data", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", versionToEtag(version))
+	w.WriteHeader(http.StatusOK)
+}
+
 func (s *Server) handleGetData(w http.ResponseWriter, r *http.Request) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	data, err := s.store.List(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load data", http.StatusInternalServerError)
+		return
+	}
 
-	s.requests++
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(s.data); err != nil {
+	if err := json.NewEncoder(w).Encode(data); err != nil {
 		http.Error(w, "Failed to encode data", http.StatusInternalServerError)
 	}
 }
 
 func (s *Server) handleGetStats(w http.ResponseWriter, r *http.Request) {
-	s.mu.Lock()
-	s.requests++
-	count := s.requests
-	s.mu.Unlock()
-
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]int{"requests": count}); err != nil {
+	if err := json.NewEncoder(w).Encode(map[string]int64{"requests": s.metrics.RequestCount()}); err != nil {
 		http.Error(w, "Failed to encode stats", http.StatusInternalServerError)
 	}
 }
 
 func (s *Server) handleDeleteData(w http.ResponseWriter, r *http.Request) {
-	key := r.PathValue("key")
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	key := mux.Vars(r)["key"]
 
-	s.requests++
+	existed, err := s.store.Delete(r.Context(), key)
+	if err != nil {
+		http.Error(w, "Failed to delete data", http.StatusInternalServerError)
+		return
+	}
 
-	if _, exists := s.data[key]; exists {
-		delete(s.data, key)
+	if existed {
 		w.WriteHeader(http.StatusOK)
 	} else {
 		http.Error(w, "Key not found", http.StatusNotFound)
@@ -100,12 +168,14 @@ func (s *Server) startBackgroundWorker() {
 	for {
 		select {
 		case <-ticker.C:
-			s.mu.Lock()
-			dataSize := len(s.data)
-			requestCount := s.requests
-			s.mu.Unlock()
-
-			log.Printf("Server Status: %d requests, %d items in database", requestCount, dataSize)
+			data, err := s.store.List(context.Background())
+			if err != nil {
+				log.Printf("Server Status: failed to read store: %v", err)
+				continue
+			}
+			s.metrics.setStoreSize(context.Background(), s.store)
+
+			log.Printf("Server Status: %d requests, %d items in database", s.metrics.RequestCount(), len(data))
 		case <-s.stopChan:
 			log.Println("Stopping background worker...")
 			return
@@ -113,18 +183,7 @@ func (s *Server) startBackgroundWorker() {
 	}
 }
 
-func (s *Server) setupHandler() *http.ServeMux {
-	mux := http.NewServeMux()
-
-	mux.HandleFunc("POST /data", s.handlePostData)
-	mux.HandleFunc("GET /data", s.handleGetData)
-	mux.HandleFunc("GET /stats", s.handleGetStats)
-	mux.HandleFunc("DELETE /data/{key}", s.handleDeleteData)
-
-	mux.HandleFunc("/stats", s.handleGetStats)
-
-	return mux
-}
+const shutdownDrainTimeout = 5 * time.Second
 
 func (s *Server) shutdown(shutdownErrChan chan<- error) {
 	stop := make(chan os.Signal, 1)
@@ -133,21 +192,39 @@ func (s *Server) shutdown(shutdownErrChan chan<- error) {
 	sig := <-stop
 	fmt.Println("Got signal:", sig)
 
-	close(s.stopChan)
+	close(s.stopChan) // flips /readyz to unavailable
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	s.drainInFlight(time.Now().Add(shutdownDrainTimeout))
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownDrainTimeout)
 	defer cancel()
 
 	fmt.Println("Gracefully shutting down...")
 	shutdownErrChan <- s.httpServer.Shutdown(ctx) // here, the graceful shutdown is called/invoked
+
+	log.Printf("Final metrics snapshot: %d total requests, %d still in flight", s.metrics.RequestCount(), s.metrics.InFlight())
+}
+
+// drainInFlight waits for in-flight requests to finish, giving active
+// handlers a chance to complete before the listener is shut down.
+func (s *Server) drainInFlight(deadline time.Time) {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for time.Now().Before(deadline) {
+		if s.metrics.InFlight() == 0 {
+			return
+		}
+		<-ticker.C
+	}
 }
 
 func (s *Server) Serve(port int) {
-	mux := s.setupHandler()
+	handler := s.setupHandler()
 
 	s.httpServer = &http.Server{
 		Addr:    ":" + strconv.Itoa(port),
-		Handler: mux,
+		Handler: handler,
 	}
 
 	shutdownError := make(chan error)
@@ -166,11 +243,25 @@ func (s *Server) Serve(port int) {
 
 	err = <-shutdownError
 	if err != nil {
-		log.Fatalf("Graceful shutdown error: %v", err)
+		log.Printf("Graceful shutdown error: %v", err)
+	}
+
+	if err := s.store.Close(); err != nil {
+		log.Printf("Error closing store: %v", err)
 	}
 }
 
 func main() {
-	server := NewServer()
-	server.Serve(4000)
+	cfg, err := LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	store, err := NewStore(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize store: %v", err)
+	}
+
+	server := NewServer(store, cfg)
+	server.Serve(cfg.Port)
 }
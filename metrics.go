@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics holds the counters and gauges exposed on /metrics and /debug.json.
+// It is the single source of truth for request statistics so numbers can't
+// drift between endpoints.
+type Metrics struct {
+	requestsTotal   *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	storeSize       prometheus.Gauge
+
+	totalRequests int64
+	inFlight      int64
+}
+
+// NewMetrics registers its collectors against reg rather than the package
+// global default registry, so multiple Metrics (e.g. one per Server in a
+// handler-level test) can coexist in the same process without a "duplicate
+// metrics collector registration" panic.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	factory := promauto.With(reg)
+	return &Metrics{
+		requestsTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total number of HTTP requests by path and status.",
+		}, []string{"path", "status"}),
+		requestDuration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by path.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"path"}),
+		storeSize: factory.NewGauge(prometheus.GaugeOpts{
+			Name: "data_store_size",
+			Help: "Number of items currently held in the data store.",
+		}),
+	}
+}
+
+func (m *Metrics) observe(path, status string, duration time.Duration) {
+	atomic.AddInt64(&m.totalRequests, 1)
+	m.requestsTotal.WithLabelValues(path, status).Inc()
+	m.requestDuration.WithLabelValues(path).Observe(duration.Seconds())
+}
+
+func (m *Metrics) requestStarted() {
+	atomic.AddInt64(&m.inFlight, 1)
+}
+
+func (m *Metrics) requestFinished() {
+	atomic.AddInt64(&m.inFlight, -1)
+}
+
+func (m *Metrics) RequestCount() int64 {
+	return atomic.LoadInt64(&m.totalRequests)
+}
+
+func (m *Metrics) InFlight() int64 {
+	return atomic.LoadInt64(&m.inFlight)
+}
+
+func (m *Metrics) setStoreSize(ctx context.Context, store Store) {
+	data, err := store.List(ctx)
+	if err != nil {
+		return
+	}
+	m.storeSize.Set(float64(len(data)))
+}
@@ -0,0 +1,15 @@
+package main
+
+import "net/http"
+
+// statusRecorder wraps a ResponseWriter to capture the status code written,
+// since http.ResponseWriter doesn't expose it after the fact.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
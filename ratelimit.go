@@ -0,0 +1,31 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"golang.org/x/time/rate"
+)
+
+// newRateLimiter builds a token-bucket limiter allowing rps requests per
+// second with bursts up to burst.
+func newRateLimiter(rps float64, burst int) *rate.Limiter {
+	return rate.NewLimiter(rate.Limit(rps), burst)
+}
+
+// rateLimited rejects requests with 429 once limiter's bucket is empty,
+// telling the client how long to back off based on the limiter's actual
+// refill rate rather than a fixed guess.
+func (s *Server) rateLimited(limiter *rate.Limiter, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reservation := limiter.Reserve()
+		if delay := reservation.Delay(); delay > 0 {
+			reservation.Cancel() // don't consume a future slot for a request we're rejecting
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Use registers global middleware that every route gets wrapped in, applied
+// in the order given.
+func (s *Server) Use(mw ...func(http.Handler) http.Handler) {
+	s.middlewares = append(s.middlewares, mw...)
+}
+
+// withMetricsMW is the mux.MiddlewareFunc form of withMetrics, reading the
+// matched route's path template so metrics are labeled by pattern rather
+// than raw URL (avoiding unbounded label cardinality from path params).
+func (s *Server) withMetricsMW(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if route := mux.CurrentRoute(r); route != nil {
+			if tmpl, err := route.GetPathTemplate(); err == nil {
+				path = tmpl
+			}
+		}
+
+		s.metrics.requestStarted()
+		defer s.metrics.requestFinished()
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		s.metrics.observe(path, strconv.Itoa(rec.status), time.Since(start))
+	})
+}
+
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, "Not found", http.StatusNotFound)
+}
+
+func methodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+}
+
+func (s *Server) setupHandler() http.Handler {
+	router := mux.NewRouter()
+	router.NotFoundHandler = http.HandlerFunc(notFoundHandler)
+	router.MethodNotAllowedHandler = http.HandlerFunc(methodNotAllowedHandler)
+
+	router.Handle("/data", s.rateLimited(s.postDataLimiter, s.requireAuth(s.handlePostData))).Methods(http.MethodPost)
+	router.Handle("/data", http.HandlerFunc(s.handleGetData)).Methods(http.MethodGet)
+	router.Handle("/stats", http.HandlerFunc(s.handleGetStats)).Methods(http.MethodGet)
+	router.Handle("/data/{key:[A-Za-z0-9_-]{1,64}}", s.requireAuth(s.handleDeleteData)).Methods(http.MethodDelete)
+	router.Handle("/data/{key:[A-Za-z0-9_-]{1,64}}", s.requireAuth(s.handlePutData)).Methods(http.MethodPut)
+
+	router.Handle("/auth/token", http.HandlerFunc(s.handleIssueToken)).Methods(http.MethodPost)
+
+	router.Handle("/debug.json", http.HandlerFunc(s.handleDebug))
+	router.Handle("/healthz", http.HandlerFunc(s.handleHealthz))
+	router.Handle("/readyz", http.HandlerFunc(s.handleReadyz))
+	router.Handle("/metrics", promhttp.HandlerFor(s.registry, promhttp.HandlerOpts{}))
+
+	s.Use(s.withMetricsMW)
+	for _, mw := range s.middlewares {
+		router.Use(mux.MiddlewareFunc(mw))
+	}
+
+	return router
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// WriteMode controls how PutBatch treats keys that already exist.
+type WriteMode string
+
+const (
+	// ModeInsert rejects the whole batch if any key already exists.
+	ModeInsert WriteMode = "insert"
+	// ModeUpsert creates or overwrites every key unconditionally.
+	ModeUpsert WriteMode = "upsert"
+	// ModeReplace rejects the whole batch if any key does not already exist.
+	ModeReplace WriteMode = "replace"
+)
+
+// ErrVersionMismatch is returned by PutIfVersion when expectedVersion
+// doesn't match the key's current version.
+var ErrVersionMismatch = errors.New("version mismatch")
+
+// Store is the persistence backend for key/value data. Implementations must
+// be safe for concurrent use. Every stored value carries a version counter,
+// starting at 1 on first write, so callers can do optimistic concurrency
+// control via PutIfVersion.
+type Store interface {
+	Get(ctx context.Context, key string) (value string, version int, ok bool, err error)
+	// PutBatch applies items atomically according to mode. On a mode
+	// violation (a duplicate key under ModeInsert, or a missing key under
+	// ModeReplace) it applies nothing and returns the offending keys.
+	PutBatch(ctx context.Context, items map[string]string, mode WriteMode) (conflicts []string, err error)
+	// PutIfVersion writes value for key only if its current version equals
+	// expectedVersion (0 meaning "key does not exist"), returning
+	// ErrVersionMismatch otherwise.
+	PutIfVersion(ctx context.Context, key, value string, expectedVersion int) (version int, err error)
+	Delete(ctx context.Context, key string) (existed bool, err error)
+	List(ctx context.Context) (map[string]string, error)
+	Close() error
+}
+
+// NewStore builds the Store configured by cfg.
+func NewStore(cfg *Config) (Store, error) {
+	switch cfg.StoreDriver {
+	case "memory", "":
+		return NewMemStore(), nil
+	case "bolt":
+		return NewBoltStore(cfg.BoltPath)
+	case "postgres":
+		return NewPostgresStore(cfg.DatabaseURL)
+	default:
+		return nil, fmt.Errorf("unknown STORE_DRIVER %q", cfg.StoreDriver)
+	}
+}
+
+type memEntry struct {
+	value   string
+	version int
+}
+
+// MemStore is an in-memory Store backed by a map. It does not persist
+// across restarts.
+type MemStore struct {
+	mu   sync.Mutex
+	data map[string]memEntry
+}
+
+func NewMemStore() *MemStore {
+	return &MemStore{data: make(map[string]memEntry)}
+}
+
+func (m *MemStore) Get(ctx context.Context, key string) (string, int, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.data[key]
+	return e.value, e.version, ok, nil
+}
+
+func (m *MemStore) PutBatch(ctx context.Context, items map[string]string, mode WriteMode) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var conflicts []string
+	switch mode {
+	case ModeInsert:
+		for k := range items {
+			if _, exists := m.data[k]; exists {
+				conflicts = append(conflicts, k)
+			}
+		}
+	case ModeReplace:
+		for k := range items {
+			if _, exists := m.data[k]; !exists {
+				conflicts = append(conflicts, k)
+			}
+		}
+	case ModeUpsert:
+		// no pre-condition
+	default:
+		return nil, fmt.Errorf("unknown write mode %q", mode)
+	}
+
+	if len(conflicts) > 0 {
+		return conflicts, nil
+	}
+
+	for k, v := range items {
+		e := m.data[k]
+		e.value = v
+		e.version++
+		m.data[k] = e
+	}
+	return nil, nil
+}
+
+func (m *MemStore) PutIfVersion(ctx context.Context, key, value string, expectedVersion int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.data[key]
+	if e.version != expectedVersion {
+		return e.version, ErrVersionMismatch
+	}
+
+	e.value = value
+	e.version++
+	m.data[key] = e
+	return e.version, nil
+}
+
+func (m *MemStore) Delete(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.data[key]
+	delete(m.data, key)
+	return ok, nil
+}
+
+func (m *MemStore) List(ctx context.Context) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]string, len(m.data))
+	for k, e := range m.data {
+		out[k] = e.value
+	}
+	return out, nil
+}
+
+func (m *MemStore) Close() error {
+	return nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+var bucketName = []byte("data")
+
+type boltValue struct {
+	Value   string `json:"value"`
+	Version int    `json:"version"`
+}
+
+// BoltStore is a Store backed by an embedded bbolt file, used for
+// single-node deployments that need durability without an external
+// database.
+type BoltStore struct {
+	db *bbolt.DB
+}
+
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bbolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketName)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func (b *BoltStore) getLocked(bucket *bbolt.Bucket, key string) (boltValue, bool, error) {
+	raw := bucket.Get([]byte(key))
+	if raw == nil {
+		return boltValue{}, false, nil
+	}
+	var v boltValue
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return boltValue{}, false, err
+	}
+	return v, true, nil
+}
+
+func (b *BoltStore) Get(ctx context.Context, key string) (string, int, bool, error) {
+	var value boltValue
+	var ok bool
+
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		v, exists, err := b.getLocked(tx.Bucket(bucketName), key)
+		if err != nil {
+			return err
+		}
+		value, ok = v, exists
+		return nil
+	})
+	return value.Value, value.Version, ok, err
+}
+
+func (b *BoltStore) PutBatch(ctx context.Context, items map[string]string, mode WriteMode) ([]string, error) {
+	var conflicts []string
+
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(bucketName)
+
+		switch mode {
+		case ModeInsert:
+			for k := range items {
+				if _, exists, err := b.getLocked(bucket, k); err != nil {
+					return err
+				} else if exists {
+					conflicts = append(conflicts, k)
+				}
+			}
+		case ModeReplace:
+			for k := range items {
+				if _, exists, err := b.getLocked(bucket, k); err != nil {
+					return err
+				} else if !exists {
+					conflicts = append(conflicts, k)
+				}
+			}
+		case ModeUpsert:
+			// no pre-condition
+		default:
+			return fmt.Errorf("unknown write mode %q", mode)
+		}
+
+		if len(conflicts) > 0 {
+			return nil
+		}
+
+		for k, v := range items {
+			current, _, err := b.getLocked(bucket, k)
+			if err != nil {
+				return err
+			}
+			current.Value = v
+			current.Version++
+
+			raw, err := json.Marshal(current)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(k), raw); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return conflicts, err
+}
+
+func (b *BoltStore) PutIfVersion(ctx context.Context, key, value string, expectedVersion int) (int, error) {
+	var version int
+
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(bucketName)
+
+		current, _, err := b.getLocked(bucket, key)
+		if err != nil {
+			return err
+		}
+		if current.Version != expectedVersion {
+			version = current.Version
+			return ErrVersionMismatch
+		}
+
+		current.Value = value
+		current.Version++
+		version = current.Version
+
+		raw, err := json.Marshal(current)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(key), raw)
+	})
+	return version, err
+}
+
+func (b *BoltStore) Delete(ctx context.Context, key string) (bool, error) {
+	var existed bool
+
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(bucketName)
+		if bucket.Get([]byte(key)) != nil {
+			existed = true
+		}
+		return bucket.Delete([]byte(key))
+	})
+	return existed, err
+}
+
+func (b *BoltStore) List(ctx context.Context) (map[string]string, error) {
+	out := make(map[string]string)
+
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucketName).ForEach(func(k, raw []byte) error {
+			var v boltValue
+			if err := json.Unmarshal(raw, &v); err != nil {
+				return err
+			}
+			out[string(k)] = v.Value
+			return nil
+		})
+	})
+	return out, err
+}
+
+func (b *BoltStore) Close() error {
+	return b.db.Close()
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// entry is the GORM model backing PostgresStore.
+type entry struct {
+	Key     string `gorm:"primaryKey"`
+	Value   string
+	Version int
+}
+
+// PostgresStore is a Store backed by Postgres via gorm, for multi-node
+// deployments that need a shared, durable backend.
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresStore(databaseURL string) (*PostgresStore, error) {
+	db, err := gorm.Open(postgres.Open(databaseURL), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&entry{}); err != nil {
+		return nil, err
+	}
+
+	return &PostgresStore{db: db}, nil
+}
+
+func (p *PostgresStore) Get(ctx context.Context, key string) (string, int, bool, error) {
+	var row entry
+	err := p.db.WithContext(ctx).First(&row, "key = ?", key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", 0, false, nil
+	}
+	if err != nil {
+		return "", 0, false, err
+	}
+	return row.Value, row.Version, true, nil
+}
+
+// PutBatch locks the rows it's about to touch with SELECT ... FOR UPDATE so
+// two concurrent batches can't both observe the same pre-write state and
+// both commit, which plain read-then-write under READ COMMITTED would
+// allow.
+func (p *PostgresStore) PutBatch(ctx context.Context, items map[string]string, mode WriteMode) ([]string, error) {
+	var conflicts []string
+
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		locked := tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		existing := make(map[string]bool, len(items))
+
+		for k := range items {
+			var row entry
+			err := locked.First(&row, "key = ?", k).Error
+			switch {
+			case err == nil:
+				existing[k] = true
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				existing[k] = false
+			default:
+				return err
+			}
+
+			switch mode {
+			case ModeInsert:
+				if existing[k] {
+					conflicts = append(conflicts, k)
+				}
+			case ModeReplace:
+				if !existing[k] {
+					conflicts = append(conflicts, k)
+				}
+			case ModeUpsert:
+				// no pre-condition
+			default:
+				return fmt.Errorf("unknown write mode %q", mode)
+			}
+		}
+
+		if len(conflicts) > 0 {
+			return nil
+		}
+
+		for k, v := range items {
+			if existing[k] {
+				err := tx.Model(&entry{}).Where("key = ?", k).
+					Updates(map[string]any{"value": v, "version": gorm.Expr("version + 1")}).Error
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			if err := tx.Create(&entry{Key: k, Value: v, Version: 1}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return conflicts, err
+}
+
+// PutIfVersion applies the write as a single conditional statement (an
+// INSERT relying on the primary key constraint, or an
+// UPDATE ... WHERE version = ?) so the compare-and-set can't be split by a
+// concurrent writer the way a separate read-then-write would be.
+func (p *PostgresStore) PutIfVersion(ctx context.Context, key, value string, expectedVersion int) (int, error) {
+	if expectedVersion == 0 {
+		err := p.db.WithContext(ctx).Create(&entry{Key: key, Value: value, Version: 1}).Error
+		if err == nil {
+			return 1, nil
+		}
+		if !isUniqueViolation(err) {
+			return 0, err
+		}
+	} else {
+		newVersion := expectedVersion + 1
+		result := p.db.WithContext(ctx).Model(&entry{}).
+			Where("key = ? AND version = ?", key, expectedVersion).
+			Updates(map[string]any{"value": value, "version": newVersion})
+		if result.Error != nil {
+			return 0, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return newVersion, nil
+		}
+	}
+
+	_, currentVersion, _, err := p.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return currentVersion, ErrVersionMismatch
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func (p *PostgresStore) Delete(ctx context.Context, key string) (bool, error) {
+	result := p.db.WithContext(ctx).Delete(&entry{}, "key = ?", key)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (p *PostgresStore) List(ctx context.Context) (map[string]string, error) {
+	var rows []entry
+	if err := p.db.WithContext(ctx).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]string, len(rows))
+	for _, row := range rows {
+		out[row.Key] = row.Value
+	}
+	return out, nil
+}
+
+func (p *PostgresStore) Close() error {
+	sqlDB, err := p.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testStoreSuite exercises the Store contract against any implementation,
+// so each backend is checked against the same behavior.
+func testStoreSuite(t *testing.T, store Store) {
+	t.Helper()
+	ctx := context.Background()
+
+	t.Run("get missing key", func(t *testing.T) {
+		_, _, ok, err := store.Get(ctx, "missing")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if ok {
+			t.Fatal("expected key to be missing")
+		}
+	})
+
+	t.Run("insert then duplicate insert conflicts", func(t *testing.T) {
+		conflicts, err := store.PutBatch(ctx, map[string]string{"a": "1"}, ModeInsert)
+		if err != nil {
+			t.Fatalf("PutBatch: %v", err)
+		}
+		if len(conflicts) != 0 {
+			t.Fatalf("unexpected conflicts: %v", conflicts)
+		}
+
+		conflicts, err = store.PutBatch(ctx, map[string]string{"a": "2", "b": "1"}, ModeInsert)
+		if err != nil {
+			t.Fatalf("PutBatch: %v", err)
+		}
+		if len(conflicts) != 1 || conflicts[0] != "a" {
+			t.Fatalf("expected conflict on key a, got %v", conflicts)
+		}
+
+		// The whole batch must have been rejected, so "b" must not exist.
+		if _, _, ok, err := store.Get(ctx, "b"); err != nil || ok {
+			t.Fatalf("expected key b to not exist after rejected batch, ok=%v err=%v", ok, err)
+		}
+
+		value, version, ok, err := store.Get(ctx, "a")
+		if err != nil || !ok || value != "1" || version != 1 {
+			t.Fatalf("expected a=1 version 1, got value=%q version=%d ok=%v err=%v", value, version, ok, err)
+		}
+	})
+
+	t.Run("replace requires existing key", func(t *testing.T) {
+		conflicts, err := store.PutBatch(ctx, map[string]string{"does-not-exist": "1"}, ModeReplace)
+		if err != nil {
+			t.Fatalf("PutBatch: %v", err)
+		}
+		if len(conflicts) != 1 {
+			t.Fatalf("expected a conflict for a missing key, got %v", conflicts)
+		}
+	})
+
+	t.Run("upsert always succeeds", func(t *testing.T) {
+		conflicts, err := store.PutBatch(ctx, map[string]string{"a": "3", "c": "1"}, ModeUpsert)
+		if err != nil {
+			t.Fatalf("PutBatch: %v", err)
+		}
+		if len(conflicts) != 0 {
+			t.Fatalf("unexpected conflicts: %v", conflicts)
+		}
+
+		value, version, ok, err := store.Get(ctx, "a")
+		if err != nil || !ok || value != "3" || version != 2 {
+			t.Fatalf("expected a=3 version 2, got value=%q version=%d ok=%v err=%v", value, version, ok, err)
+		}
+	})
+
+	t.Run("PutIfVersion rejects stale version", func(t *testing.T) {
+		_, version, ok, err := store.Get(ctx, "a")
+		if err != nil || !ok {
+			t.Fatalf("Get: ok=%v err=%v", ok, err)
+		}
+
+		if _, err := store.PutIfVersion(ctx, "a", "stale", version-1); !errors.Is(err, ErrVersionMismatch) {
+			t.Fatalf("expected ErrVersionMismatch, got %v", err)
+		}
+
+		newVersion, err := store.PutIfVersion(ctx, "a", "4", version)
+		if err != nil {
+			t.Fatalf("PutIfVersion: %v", err)
+		}
+		if newVersion != version+1 {
+			t.Fatalf("expected version %d, got %d", version+1, newVersion)
+		}
+	})
+
+	t.Run("PutIfVersion creates with expected version 0", func(t *testing.T) {
+		version, err := store.PutIfVersion(ctx, "new-key", "first", 0)
+		if err != nil {
+			t.Fatalf("PutIfVersion: %v", err)
+		}
+		if version != 1 {
+			t.Fatalf("expected version 1, got %d", version)
+		}
+
+		if _, err := store.PutIfVersion(ctx, "new-key", "again", 0); !errors.Is(err, ErrVersionMismatch) {
+			t.Fatalf("expected ErrVersionMismatch for already-created key, got %v", err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		existed, err := store.Delete(ctx, "c")
+		if err != nil || !existed {
+			t.Fatalf("expected existing key to be deleted, existed=%v err=%v", existed, err)
+		}
+
+		existed, err = store.Delete(ctx, "c")
+		if err != nil || existed {
+			t.Fatalf("expected already-deleted key, existed=%v err=%v", existed, err)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		data, err := store.List(ctx)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if _, ok := data["a"]; !ok {
+			t.Fatalf("expected key a in listing, got %v", data)
+		}
+	})
+}
+
+func TestMemStore(t *testing.T) {
+	store := NewMemStore()
+	defer store.Close()
+
+	testStoreSuite(t, store)
+}
+
+func TestBoltStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.db")
+	store, err := NewBoltStore(path)
+	if err != nil {
+		t.Fatalf("NewBoltStore: %v", err)
+	}
+	defer store.Close()
+
+	testStoreSuite(t, store)
+}
+
+func TestPostgresStore(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL not set, skipping Postgres integration test")
+	}
+
+	store, err := NewPostgresStore(databaseURL)
+	if err != nil {
+		t.Fatalf("NewPostgresStore: %v", err)
+	}
+	defer store.Close()
+
+	testStoreSuite(t, store)
+}